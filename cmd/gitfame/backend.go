@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// BlameLine описывает одну строку файла по результатам blame: коммит, в котором она
+// появилась, и его автор/коммиттер.
+type BlameLine struct {
+	Commit    string
+	Author    string
+	Committer string
+}
+
+// IgnoreRevs собирает ревизии, которые нужно исключить из blame (mass-reformat/license-header
+// коммиты), в двух формах: явные SHA (--ignore-rev) и пути к файлам в формате
+// --ignore-revs-file (явно заданный файл и/или автообнаруженный .git-blame-ignore-revs).
+type IgnoreRevs struct {
+	Revs  []string
+	Files []string
+}
+
+// Empty сообщает, что список игнорируемых ревизий пуст.
+func (ir IgnoreRevs) Empty() bool {
+	return len(ir.Revs) == 0 && len(ir.Files) == 0
+}
+
+// Backend абстрагирует обращение к git, чтобы анализ можно было выполнять либо через
+// внешний git-бинарь, либо через библиотеку go-git без порождения подпроцессов.
+type Backend interface {
+	// ListFiles возвращает список файлов, отслеживаемых в дереве указанной ревизии.
+	ListFiles(ctx context.Context, rev string) ([]string, error)
+	// BlameFile возвращает построчную историю авторства файла на указанной ревизии. Строки,
+	// чей коммит попадает в ignore, переадресуются на ближайшего неигнорируемого предка —
+	// так же, как это делает `git blame --ignore-rev`/`--ignore-revs-file`.
+	BlameFile(ctx context.Context, rev, path string, ignore IgnoreRevs) ([]BlameLine, error)
+	// LastCommit возвращает хэш, автора и коммиттера последнего коммита, затронувшего path.
+	LastCommit(ctx context.Context, rev, path string) (hash, author, committer string, err error)
+}
+
+// execBackend реализует Backend, порождая процессы системного git-бинаря.
+type execBackend struct {
+	repoPath string
+}
+
+func newExecBackend(repoPath string) *execBackend {
+	return &execBackend{repoPath: repoPath}
+}
+
+func (b *execBackend) ListFiles(ctx context.Context, rev string) ([]string, error) {
+	revCmd := exec.CommandContext(ctx, "git", "-C", b.repoPath, "rev-parse", "--verify", rev)
+	if err := revCmd.Run(); err != nil {
+		return nil, fmt.Errorf("неверная ревизия (%s): %v", rev, err)
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", b.repoPath, "ls-tree", "-r", "--name-only", rev)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git ls-tree failed: %v", err)
+	}
+	rawLines := strings.Split(out.String(), "\n")
+	var lines []string
+	for _, line := range rawLines {
+		if trimmed := strings.TrimSpace(line); len(trimmed) > 0 {
+			lines = append(lines, trimmed)
+		}
+	}
+	return lines, nil
+}
+
+func (b *execBackend) BlameFile(ctx context.Context, rev, path string, ignore IgnoreRevs) ([]BlameLine, error) {
+	commitAuthors := make(map[string]BlameLine)
+	args := []string{"-C", b.repoPath, "blame", "--porcelain", "-l"}
+	for _, sha := range ignore.Revs {
+		args = append(args, "--ignore-rev", sha)
+	}
+	for _, file := range ignore.Files {
+		args = append(args, "--ignore-revs-file", file)
+	}
+	args = append(args, rev, "--", path)
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка git blame: %v", err)
+	}
+
+	var lines []BlameLine
+	var block []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "" {
+			continue
+		}
+		if line[0] == '\t' {
+			if len(block) == 0 {
+				continue
+			}
+			commit := strings.Fields(block[0])[0]
+			bl, ok := commitAuthors[commit]
+			if !ok {
+				bl.Commit = commit
+				for _, field := range block {
+					if strings.HasPrefix(field, "author ") {
+						bl.Author = strings.TrimSpace(field[len("author "):])
+					}
+					if strings.HasPrefix(field, "committer ") {
+						bl.Committer = strings.TrimSpace(field[len("committer "):])
+					}
+				}
+				commitAuthors[commit] = bl
+			}
+			lines = append(lines, bl)
+			block = nil
+			continue
+		}
+		block = append(block, line)
+	}
+	return lines, nil
+}
+
+func (b *execBackend) LastCommit(ctx context.Context, rev, path string) (string, string, string, error) {
+	cmdHash := exec.CommandContext(ctx, "git", "-C", b.repoPath, "log", rev, "-1", "--format=%H", "--", path)
+	hashBytes, err := cmdHash.Output()
+	if err != nil {
+		return "", "", "", fmt.Errorf("git log failed: %v", err)
+	}
+	hash := strings.TrimSpace(string(hashBytes))
+
+	cmdNames := exec.CommandContext(ctx, "git", "-C", b.repoPath, "log", rev, "-1", "--format=%an%x00%cn", "--", path)
+	namesBytes, err := cmdNames.Output()
+	if err != nil {
+		return "", "", "", fmt.Errorf("git log failed (author): %v", err)
+	}
+	names := strings.SplitN(strings.TrimSpace(string(namesBytes)), "\x00", 2)
+	author := names[0]
+	committer := author
+	if len(names) == 2 {
+		committer = names[1]
+	}
+	return hash, author, committer, nil
+}
+
+// gogitBackend реализует Backend поверх go-git, открывая репозиторий один раз и переиспользуя
+// его объектный кэш между файлами, что избавляет от накладных расходов на запуск процесса
+// git-бинаря для каждого файла. go-git не гарантирует потокобезопасность Repository/storer
+// при конкурентных вызовах, а collectBlameStats разбирает файлы несколькими воркерами
+// одновременно, поэтому все обращения к repo сериализуются через mu.
+type gogitBackend struct {
+	repoPath string
+	repo     *git.Repository
+	mu       sync.Mutex
+}
+
+func newGogitBackend(repoPath string) (*gogitBackend, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть репозиторий через go-git: %v", err)
+	}
+	return &gogitBackend{repoPath: repoPath, repo: repo}, nil
+}
+
+func (b *gogitBackend) resolveCommit(rev string) (*object.Commit, error) {
+	hash, err := b.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("неверная ревизия (%s): %v", rev, err)
+	}
+	return b.repo.CommitObject(*hash)
+}
+
+func (b *gogitBackend) ListFiles(ctx context.Context, rev string) ([]string, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	commit, err := b.resolveCommit(rev)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить дерево коммита: %v", err)
+	}
+
+	var files []string
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		files = append(files, f.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось перечислить файлы дерева: %v", err)
+	}
+	return files, nil
+}
+
+func (b *gogitBackend) BlameFile(ctx context.Context, rev, path string, ignore IgnoreRevs) ([]BlameLine, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if !ignore.Empty() {
+		// go-git не умеет "вынуть" ревизию из истории и продолжить тот же diff, которым
+		// `git blame --ignore-rev` переназначает авторство построчно. Приближение через
+		// повторный blame на родителе и сопоставление строк по тексту ломается на файлах с
+		// повторяющимся содержимым (пустые строки, одинокая "}", license-заголовки,
+		// import-строки) — ровно на том, что чаще всего трогают mass-reformat коммиты,
+		// которые --ignore-rev и должен скрывать: совпадение находится по первой строке с
+		// таким же текстом, а не по настоящей истории diff'а. Поэтому при активных
+		// ignore-revs делегируем на execBackend, который передаёт --ignore-rev/
+		// --ignore-revs-file напрямую git и получает точное построчное переназначение.
+		return newExecBackend(b.repoPath).BlameFile(ctx, rev, path, ignore)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	commit, err := b.resolveCommit(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := git.Blame(commit, path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка go-git blame: %v", err)
+	}
+
+	commitInfo := make(map[plumbing.Hash]BlameLine)
+	lines := make([]BlameLine, 0, len(result.Lines))
+	for _, l := range result.Lines {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		bl, ok := commitInfo[l.Hash]
+		if !ok {
+			lineCommit, cerr := b.repo.CommitObject(l.Hash)
+			if cerr != nil {
+				return nil, fmt.Errorf("не удалось получить коммит %s: %v", l.Hash, cerr)
+			}
+			bl.Commit = lineCommit.Hash.String()
+			bl.Author = lineCommit.Author.Name
+			bl.Committer = lineCommit.Committer.Name
+			commitInfo[l.Hash] = bl
+		}
+		lines = append(lines, bl)
+	}
+	return lines, nil
+}
+
+func (b *gogitBackend) LastCommit(ctx context.Context, rev, path string) (string, string, string, error) {
+	if ctx.Err() != nil {
+		return "", "", "", ctx.Err()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	commit, err := b.resolveCommit(rev)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	commitIter, err := b.repo.Log(&git.LogOptions{From: commit.Hash, FileName: &path})
+	if err != nil {
+		return "", "", "", fmt.Errorf("не удалось получить историю файла: %v", err)
+	}
+	defer commitIter.Close()
+
+	last, err := commitIter.Next()
+	if err != nil {
+		return "", "", "", fmt.Errorf("файл отсутствует в истории (%s): %v", path, err)
+	}
+	return last.Hash.String(), last.Author.Name, last.Committer.Name, nil
+}
+
+// newBackend создаёт реализацию Backend по имени, переданному через флаг --backend.
+func newBackend(name, repoPath string) (Backend, error) {
+	switch name {
+	case "exec":
+		return newExecBackend(repoPath), nil
+	case "go-git":
+		return newGogitBackend(repoPath)
+	default:
+		return nil, fmt.Errorf("--backend может быть exec или go-git, но получено: %q", name)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// TestGogitBackendConcurrentBlame exercises BlameFile from multiple goroutines at once, the
+// way collectBlameStats's worker pool does against the default go-git backend. Run with
+// -race: before gogitBackend serialized access to the shared *git.Repository, this reliably
+// raced.
+func TestGogitBackendConcurrentBlame(t *testing.T) {
+	backend, err := newGogitBackend("../..")
+	if err != nil {
+		t.Fatalf("newGogitBackend: %v", err)
+	}
+
+	files := []string{
+		"cmd/gitfame/main.go",
+		"cmd/gitfame/backend.go",
+		"cmd/gitfame/classifier.go",
+		"cmd/gitfame/vendor.go",
+		"cmd/gitfame/ignorerevs.go",
+		"internal/gitattr/gitattr.go",
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(files)*4)
+	for i := 0; i < 4; i++ {
+		for _, file := range files {
+			wg.Add(1)
+			go func(file string) {
+				defer wg.Done()
+				if _, err := backend.BlameFile(context.Background(), "HEAD", file, IgnoreRevs{}); err != nil {
+					errs <- err
+				}
+			}(file)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("concurrent BlameFile: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tokenPattern выделяет из содержимого файла слова и идентификаторы, по которым строится
+// частотная модель языка (упрощённый аналог токенизации, которую использует enry).
+var tokenPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// tokenize разбивает содержимое файла на токены в нижнем регистре.
+func tokenize(content []byte) []string {
+	matches := tokenPattern.FindAll(content, -1)
+	tokens := make([]string, len(matches))
+	for i, m := range matches {
+		tokens[i] = strings.ToLower(string(m))
+	}
+	return tokens
+}
+
+// shebangInterpreter возвращает имя интерпретатора из shebang-строки файла (например,
+// "python3" для "#!/usr/bin/env python3"), либо пустую строку, если shebang отсутствует.
+func shebangInterpreter(content []byte) string {
+	nl := strings.IndexByte(string(content), '\n')
+	firstLine := string(content)
+	if nl >= 0 {
+		firstLine = firstLine[:nl]
+	}
+	firstLine = strings.TrimSpace(firstLine)
+	if !strings.HasPrefix(firstLine, "#!") {
+		return ""
+	}
+	fields := strings.Fields(firstLine[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" && len(fields) > 1 {
+		interpreter = filepath.Base(fields[1])
+	}
+	return interpreter
+}
+
+// Classifier классифицирует файлы по языку на основе содержимого, когда одного расширения
+// недостаточно (неоднозначные расширения вроде .h или .m) либо оно вовсе отсутствует
+// (Makefile, Dockerfile, скрипты с shebang). Модель строится при инициализации по полям
+// Keywords/Filenames/Interpreters языкового конфига.
+type Classifier struct {
+	extIndex         map[string][]string
+	filenameIndex    map[string]string
+	interpreterIndex map[string]string
+	tokenFreq        map[string]map[string]float64
+	tokenTotal       map[string]float64
+	vocab            map[string]struct{}
+}
+
+// NewClassifier строит индексы и частотную модель токенов по списку языков из конфига.
+func NewClassifier(langs []Language) *Classifier {
+	c := &Classifier{
+		extIndex:         make(map[string][]string),
+		filenameIndex:    make(map[string]string),
+		interpreterIndex: make(map[string]string),
+		tokenFreq:        make(map[string]map[string]float64),
+		tokenTotal:       make(map[string]float64),
+		vocab:            make(map[string]struct{}),
+	}
+
+	for _, lang := range langs {
+		for _, ext := range lang.Extensions {
+			ext = strings.ToLower(ext)
+			c.extIndex[ext] = append(c.extIndex[ext], lang.Name)
+		}
+		for _, name := range lang.Filenames {
+			c.filenameIndex[name] = lang.Name
+		}
+		for _, interp := range lang.Interpreters {
+			c.interpreterIndex[interp] = lang.Name
+		}
+
+		freq := make(map[string]float64)
+		for _, keyword := range lang.Keywords {
+			for _, tok := range tokenize([]byte(keyword)) {
+				freq[tok]++
+				c.tokenTotal[lang.Name]++
+				c.vocab[tok] = struct{}{}
+			}
+		}
+		c.tokenFreq[lang.Name] = freq
+	}
+
+	return c
+}
+
+// Trained сообщает, построена ли содержательная частотная модель, то есть нашёлся хотя бы
+// один токен из поля Keywords хотя бы одного языка. Без обученной модели каждый кандидат
+// получает в ClassifyFile одинаковый log-score, и сортировка вырождается в алфавитную — в
+// этом случае классификацию по содержимому нужно пропускать, а не делать вид, что она что-то
+// различает.
+func (c *Classifier) Trained() bool {
+	return len(c.vocab) > 0
+}
+
+// Candidates определяет языки-кандидаты для файла по его имени, расширению и (при
+// необходимости) shebang-интерпретатору. Возвращает равные априорные веса для всех
+// кандидатов, которые затем уточняются в ClassifyFile по содержимому файла.
+func (c *Classifier) Candidates(path string, content []byte) map[string]float64 {
+	base := filepath.Base(path)
+	if lang, ok := c.filenameIndex[base]; ok {
+		return map[string]float64{lang: 1}
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if names, ok := c.extIndex[ext]; ok && len(names) > 0 {
+		weight := 1 / float64(len(names))
+		candidates := make(map[string]float64, len(names))
+		for _, name := range names {
+			candidates[name] = weight
+		}
+		return candidates
+	}
+
+	if interp := shebangInterpreter(content); interp != "" {
+		if lang, ok := c.interpreterIndex[interp]; ok {
+			return map[string]float64{lang: 1}
+		}
+	}
+
+	return nil
+}
+
+// ClassifyFile оценивает каждый язык-кандидат по log-правдоподобию токенов содержимого
+// файла (наивный байесовский классификатор со сглаживанием Лапласа) и возвращает языки,
+// отсортированные по убыванию оценки.
+func (c *Classifier) ClassifyFile(path string, content []byte, candidates map[string]float64) []string {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	tokens := tokenize(content)
+	vocabSize := float64(len(c.vocab))
+
+	type scoredLang struct {
+		name  string
+		score float64
+	}
+	scored := make([]scoredLang, 0, len(candidates))
+	for name, prior := range candidates {
+		logProb := math.Log(prior)
+		freq := c.tokenFreq[name]
+		total := c.tokenTotal[name]
+		for _, tok := range tokens {
+			logProb += math.Log((freq[tok] + 1) / (total + vocabSize + 1))
+		}
+		scored = append(scored, scoredLang{name: name, score: logProb})
+	}
+
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].name < scored[j].name
+	})
+
+	result := make([]string, len(scored))
+	for i, s := range scored {
+		result[i] = s.name
+	}
+	return result
+}
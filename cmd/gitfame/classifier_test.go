@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifierUntrainedIsNoop(t *testing.T) {
+	c := NewClassifier([]Language{
+		{Name: "C", Extensions: []string{".h"}},
+		{Name: "C++", Extensions: []string{".h"}},
+	})
+	if c.Trained() {
+		t.Fatal("Trained() = true for a config with no Keywords")
+	}
+}
+
+func TestClassifierRanksByKeywordOverlap(t *testing.T) {
+	langs := []Language{
+		{Name: "C", Extensions: []string{".h"}, Keywords: []string{"printf malloc free struct typedef"}},
+		{Name: "C++", Extensions: []string{".h"}, Keywords: []string{"template namespace std cout vector class"}},
+	}
+	c := NewClassifier(langs)
+	if !c.Trained() {
+		t.Fatal("Trained() = false with Keywords present")
+	}
+
+	cppContent := []byte(`#include <vector>
+namespace app {
+	template <typename T> class Widget {
+		std::vector<T> items;
+	};
+}
+`)
+	candidates := c.Candidates("widget.h", cppContent)
+	ranked := c.ClassifyFile("widget.h", cppContent, candidates)
+	if len(ranked) == 0 || ranked[0] != "C++" {
+		t.Fatalf("ClassifyFile(C++-flavored .h) = %v, want top result C++", ranked)
+	}
+
+	cContent := []byte(`#include <stdlib.h>
+struct Point { int x; int y; };
+void *alloc(size_t n) { return malloc(n); }
+`)
+	candidates = c.Candidates("point.h", cContent)
+	ranked = c.ClassifyFile("point.h", cContent, candidates)
+	if len(ranked) == 0 || ranked[0] != "C" {
+		t.Fatalf("ClassifyFile(C-flavored .h) = %v, want top result C", ranked)
+	}
+}
+
+func TestClassifierCandidatesByFilenameAndShebang(t *testing.T) {
+	langs := []Language{
+		{Name: "Makefile", Filenames: []string{"Makefile"}},
+		{Name: "Python", Interpreters: []string{"python3", "python"}},
+	}
+	c := NewClassifier(langs)
+
+	if got := c.Candidates("Makefile", nil); !reflect.DeepEqual(got, map[string]float64{"Makefile": 1}) {
+		t.Fatalf("Candidates(Makefile) = %v", got)
+	}
+
+	script := []byte("#!/usr/bin/env python3\nprint('hi')\n")
+	if got := c.Candidates("deploy", script); !reflect.DeepEqual(got, map[string]float64{"Python": 1}) {
+		t.Fatalf("Candidates(shebang script) = %v", got)
+	}
+
+	if got := c.Candidates("unknown.zzz", nil); got != nil {
+		t.Fatalf("Candidates(unrecognized) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// runGit executes git in dir, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// newIgnoreRevsTestRepo строит репозиторий из трёх коммитов: первый вводит строку, второй —
+// "форматирующий" коммит, который переписывает файл, не меняя содержимое строки, третий
+// добавляет новую строку. Это минимальная форма сценария --ignore-rev из чанка chunk0-5:
+// при игнорировании форматирующего коммита неизменная строка должна остаться за первым
+// коммитом, а не "повиснуть" на форматирующем.
+func newIgnoreRevsTestRepo(t *testing.T) (dir string, reformatSHA string) {
+	t.Helper()
+	dir = t.TempDir()
+	runGit(t, dir, "init", "-q")
+
+	file := filepath.Join(dir, "greet.txt")
+	if err := os.WriteFile(file, []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "add greeting")
+
+	// "Форматирующий" коммит: оборачивает файл маркерами, не меняя саму строку "hello".
+	if err := os.WriteFile(file, []byte("# begin\nhello\n# end\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-aq", "-m", "reformat")
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reformatSHA = string(out[:40])
+
+	if err := os.WriteFile(file, []byte("# begin\nhello\nworld\n# end\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-aq", "-m", "add world")
+
+	return dir, reformatSHA
+}
+
+func TestGogitIgnoreRevMatchesExecBackend(t *testing.T) {
+	dir, reformatSHA := newIgnoreRevsTestRepo(t)
+	ignore := IgnoreRevs{Revs: []string{reformatSHA}}
+
+	execBackend := newExecBackend(dir)
+	want, err := execBackend.BlameFile(context.Background(), "HEAD", "greet.txt", ignore)
+	if err != nil {
+		t.Fatalf("execBackend.BlameFile: %v", err)
+	}
+
+	gogit, err := newGogitBackend(dir)
+	if err != nil {
+		t.Fatalf("newGogitBackend: %v", err)
+	}
+	got, err := gogit.BlameFile(context.Background(), "HEAD", "greet.txt", ignore)
+	if err != nil {
+		t.Fatalf("gogitBackend.BlameFile: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("line count mismatch: go-git=%d exec=%d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Commit != want[i].Commit {
+			t.Errorf("line %d: go-git attributes to %s, exec attributes to %s", i, got[i].Commit, want[i].Commit)
+		}
+	}
+}
+
+// newDuplicateLinesTestRepo строит репозиторий, где несколько строк с одинаковым текстом
+// (пустые строки и повторяющаяся "}") появляются в разных коммитах. Раньше go-git
+// переназначал игнорируемую строку на первую попавшуюся строку с совпадающим текстом у
+// родителя, что путает авторство именно на таком повторяющемся содержимом — ровно на том,
+// что чаще всего трогают mass-reformat коммиты.
+func newDuplicateLinesTestRepo(t *testing.T) (dir string, reformatSHA string) {
+	t.Helper()
+	dir = t.TempDir()
+	runGit(t, dir, "init", "-q")
+
+	file := filepath.Join(dir, "code.go")
+	if err := os.WriteFile(file, []byte("func a() {\n}\n\nfunc b() {\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "add a and b")
+
+	// "Форматирующий" коммит: вставляет третью функцию между a и b, добавляя ещё одну
+	// пустую строку и ещё одну "}" — оба текста уже встречались в файле раньше.
+	if err := os.WriteFile(file, []byte("func a() {\n}\n\nfunc c() {\n}\n\nfunc b() {\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-aq", "-m", "reformat: insert c between a and b")
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reformatSHA = string(out[:40])
+
+	if err := os.WriteFile(file, []byte("func a() {\n}\n\nfunc c() {\n}\n\nfunc b() {\n}\n\nfunc d() {\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "commit", "-aq", "-m", "add d")
+
+	return dir, reformatSHA
+}
+
+func TestGogitIgnoreRevMatchesExecBackendWithDuplicateLines(t *testing.T) {
+	dir, reformatSHA := newDuplicateLinesTestRepo(t)
+	ignore := IgnoreRevs{Revs: []string{reformatSHA}}
+
+	execBackend := newExecBackend(dir)
+	want, err := execBackend.BlameFile(context.Background(), "HEAD", "code.go", ignore)
+	if err != nil {
+		t.Fatalf("execBackend.BlameFile: %v", err)
+	}
+
+	gogit, err := newGogitBackend(dir)
+	if err != nil {
+		t.Fatalf("newGogitBackend: %v", err)
+	}
+	got, err := gogit.BlameFile(context.Background(), "HEAD", "code.go", ignore)
+	if err != nil {
+		t.Fatalf("gogitBackend.BlameFile: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("line count mismatch: go-git=%d exec=%d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Commit != want[i].Commit {
+			t.Errorf("line %d: go-git attributes to %s, exec attributes to %s", i, got[i].Commit, want[i].Commit)
+		}
+	}
+}
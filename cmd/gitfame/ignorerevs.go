@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stringSliceFlag реализует flag.Value и накапливает значения повторяемого флага
+// (используется для --ignore-rev, который можно указывать несколько раз).
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// loadIgnoreRevsFile читает файл со списком SHA коммитов для игнорирования при blame: формат
+// идентичен --ignore-revs-file у git blame — по одной ревизии на строку, пустые строки и
+// строки, начинающиеся с '#', пропускаются.
+func loadIgnoreRevsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл игнорируемых ревизий: %v", err)
+	}
+	defer f.Close()
+
+	var revs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		revs = append(revs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла игнорируемых ревизий: %v", err)
+	}
+	return revs, nil
+}
+
+// resolveIgnoreRevs собирает итоговый IgnoreRevs: явные --ignore-rev, --ignore-revs-file и,
+// если не отключено флагом --no-auto-ignore-revs, автообнаруженный .git-blame-ignore-revs
+// в корне репозитория.
+func resolveIgnoreRevs(repoPath string, ignoreRevFlags []string, ignoreRevsFile string, autoDetect bool) (IgnoreRevs, error) {
+	ignore := IgnoreRevs{Revs: append([]string(nil), ignoreRevFlags...)}
+
+	if ignoreRevsFile != "" {
+		if _, err := os.Stat(ignoreRevsFile); err != nil {
+			return IgnoreRevs{}, fmt.Errorf("не удалось открыть файл игнорируемых ревизий: %v", err)
+		}
+		ignore.Files = append(ignore.Files, ignoreRevsFile)
+	}
+
+	if autoDetect {
+		autoPath := filepath.Join(repoPath, ".git-blame-ignore-revs")
+		if _, err := os.Stat(autoPath); err == nil && autoPath != ignoreRevsFile {
+			ignore.Files = append(ignore.Files, autoPath)
+		}
+	}
+
+	return ignore, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadIgnoreRevsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ignore-revs")
+	content := "# mass reformat\nabc123\n\n  def456  \n# trailing comment\nghi789\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loadIgnoreRevsFile(path)
+	if err != nil {
+		t.Fatalf("loadIgnoreRevsFile: %v", err)
+	}
+	want := []string{"abc123", "def456", "ghi789"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadIgnoreRevsFile() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadIgnoreRevsFileMissing(t *testing.T) {
+	if _, err := loadIgnoreRevsFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("loadIgnoreRevsFile(missing file) = nil error, want error")
+	}
+}
+
+func TestResolveIgnoreRevsAutoDetect(t *testing.T) {
+	repoDir := t.TempDir()
+	autoPath := filepath.Join(repoDir, ".git-blame-ignore-revs")
+	if err := os.WriteFile(autoPath, []byte("abc123\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ignore, err := resolveIgnoreRevs(repoDir, []string{"explicit1"}, "", true)
+	if err != nil {
+		t.Fatalf("resolveIgnoreRevs: %v", err)
+	}
+	if !reflect.DeepEqual(ignore.Revs, []string{"explicit1"}) {
+		t.Errorf("Revs = %v, want [explicit1]", ignore.Revs)
+	}
+	if !reflect.DeepEqual(ignore.Files, []string{autoPath}) {
+		t.Errorf("Files = %v, want [%s]", ignore.Files, autoPath)
+	}
+}
+
+func TestResolveIgnoreRevsAutoDetectDisabled(t *testing.T) {
+	repoDir := t.TempDir()
+	autoPath := filepath.Join(repoDir, ".git-blame-ignore-revs")
+	if err := os.WriteFile(autoPath, []byte("abc123\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ignore, err := resolveIgnoreRevs(repoDir, nil, "", false)
+	if err != nil {
+		t.Fatalf("resolveIgnoreRevs: %v", err)
+	}
+	if len(ignore.Files) != 0 {
+		t.Errorf("Files = %v, want empty when autoDetect is false", ignore.Files)
+	}
+}
+
+func TestResolveIgnoreRevsExplicitFileNotDuplicated(t *testing.T) {
+	repoDir := t.TempDir()
+	autoPath := filepath.Join(repoDir, ".git-blame-ignore-revs")
+	if err := os.WriteFile(autoPath, []byte("abc123\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ignore, err := resolveIgnoreRevs(repoDir, nil, autoPath, true)
+	if err != nil {
+		t.Fatalf("resolveIgnoreRevs: %v", err)
+	}
+	if !reflect.DeepEqual(ignore.Files, []string{autoPath}) {
+		t.Errorf("Files = %v, want [%s] without duplication", ignore.Files, autoPath)
+	}
+}
+
+func TestResolveIgnoreRevsMissingExplicitFile(t *testing.T) {
+	repoDir := t.TempDir()
+	if _, err := resolveIgnoreRevs(repoDir, nil, filepath.Join(repoDir, "missing"), false); err == nil {
+		t.Fatal("resolveIgnoreRevs(missing --ignore-revs-file) = nil error, want error")
+	}
+}
@@ -1,24 +1,46 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/makhmudovs1/gitfame/internal/gitattr"
 )
 
-// Language описывает структуру языка для загрузки из JSON-конфига.
+// Language описывает структуру языка для загрузки из JSON-конфига. Поля Filenames,
+// Interpreters и Keywords используются классификатором (см. classifier.go) для файлов,
+// у которых расширение не определяет язык однозначно или отсутствует вовсе.
 type Language struct {
-	Name       string   `json:"name"`
-	Type       string   `json:"type"`
-	Extensions []string `json:"extensions"`
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Extensions   []string `json:"extensions"`
+	Filenames    []string `json:"filenames,omitempty"`
+	Interpreters []string `json:"interpreters,omitempty"`
+	Keywords     []string `json:"keywords,omitempty"`
+}
+
+// loadLanguages читает конфигурационный файл языков целиком, без фильтрации по именам.
+func loadLanguages(path string) ([]Language, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл конфигурации: %v", err)
+	}
+	var langs []Language
+	if err := json.Unmarshal(data, &langs); err != nil {
+		return nil, fmt.Errorf("ошибка разбора JSON: %v", err)
+	}
+	return langs, nil
 }
 
 // AuthorStats хранит статистику по конкретному автору (строки, коммиты, файлы).
@@ -75,6 +97,32 @@ func filterExtension(files []string, extensions []string) []string {
 	return filtered
 }
 
+// filterByClassification отбирает файлы, чьё содержимое классификатор относит к одному из
+// запрошенных языков. Файлы, которые не удалось прочитать или классифицировать, отбрасываются.
+func filterByClassification(files []string, repoPath string, classifier *Classifier, languages []string) []string {
+	wanted := make(map[string]struct{}, len(languages))
+	for _, lang := range languages {
+		wanted[strings.ToLower(lang)] = struct{}{}
+	}
+
+	var filtered []string
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(repoPath, file))
+		if err != nil {
+			continue
+		}
+		candidates := classifier.Candidates(file, content)
+		ranked := classifier.ClassifyFile(file, content, candidates)
+		if len(ranked) == 0 {
+			continue
+		}
+		if _, ok := wanted[strings.ToLower(ranked[0])]; ok {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
+
 // isFileEmpty проверяет, что файл существует и имеет размер 0.
 func isFileEmpty(repoPath, file string) bool {
 	info, err := os.Stat(filepath.Join(repoPath, file))
@@ -85,24 +133,14 @@ func isFileEmpty(repoPath, file string) bool {
 }
 
 // blameEmptyFile обрабатывает случай, когда файл пустой.
-func blameEmptyFile(repoPath, revision, file string, useCommitter bool) (map[string]*AuthorStats, error) {
-	cmdHash := exec.Command("git", "-C", repoPath, "log", revision, "-1", "--format=%H", "--", file)
-	hashBytes, err := cmdHash.Output()
+func blameEmptyFile(ctx context.Context, backend Backend, revision, file string, useCommitter bool) (map[string]*AuthorStats, error) {
+	commitHash, author, committer, err := backend.LastCommit(ctx, revision, file)
 	if err != nil {
-		return nil, fmt.Errorf("git log failed: %v", err)
+		return nil, err
 	}
-	commitHash := strings.TrimSpace(string(hashBytes))
-
-	formatArg := "%an"
 	if useCommitter {
-		formatArg = "%cn"
-	}
-	cmdAuthor := exec.Command("git", "-C", repoPath, "log", revision, "-1", "--format="+formatArg, "--", file)
-	authorBytes, err := cmdAuthor.Output()
-	if err != nil {
-		return nil, fmt.Errorf("git log failed (author): %v", err)
+		author = committer
 	}
-	author := strings.TrimSpace(string(authorBytes))
 
 	stats := make(map[string]*AuthorStats)
 	stats[author] = &AuthorStats{
@@ -115,62 +153,31 @@ func blameEmptyFile(repoPath, revision, file string, useCommitter bool) (map[str
 }
 
 // blameFile возвращает статистику по строкам для каждого автора файла.
-func blameFile(repoPath, revision, filePath string, useCommitter bool) (map[string]*AuthorStats, error) {
-	commitToAuthor := make(map[string]string)
-	cmd := exec.Command("git", "-C", repoPath, "blame", "--porcelain", "-l", revision, "--", filePath)
-	out, err := cmd.Output()
+func blameFile(ctx context.Context, backend Backend, revision, filePath string, useCommitter bool, ignore IgnoreRevs) (map[string]*AuthorStats, error) {
+	blameLines, err := backend.BlameFile(ctx, revision, filePath, ignore)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка git blame: %v", err)
+		return nil, err
 	}
-	lines := strings.Split(string(out), "\n")
-	stats := make(map[string]*AuthorStats)
 
-	var block []string
-	for _, line := range lines {
-		if line == "" {
-			continue
+	stats := make(map[string]*AuthorStats)
+	for _, bl := range blameLines {
+		author := bl.Author
+		if useCommitter {
+			author = bl.Committer
 		}
-		if line[0] == '\t' {
-			if len(block) == 0 {
-				continue
-			}
-			headerFields := strings.Fields(block[0])
-			commit := headerFields[0]
-			var author string
-
-			for _, bline := range block {
-				if !useCommitter && strings.HasPrefix(bline, "author ") {
-					author = strings.TrimSpace(bline[len("author "):])
-					break
-				}
-				if useCommitter && strings.HasPrefix(bline, "committer ") {
-					author = strings.TrimSpace(strings.Join(strings.Fields(bline)[1:], " "))
-					break
-				}
-			}
-			if author == "" {
-				author = commitToAuthor[commit]
-			} else {
-				commitToAuthor[commit] = author
-			}
 
-			stat, ok := stats[author]
-			if !ok {
-				stat = &AuthorStats{
-					Name:    author,
-					Commits: make(map[string]struct{}),
-					Files:   make(map[string]struct{}),
-				}
-				stats[author] = stat
+		stat, ok := stats[author]
+		if !ok {
+			stat = &AuthorStats{
+				Name:    author,
+				Commits: make(map[string]struct{}),
+				Files:   make(map[string]struct{}),
 			}
-			stat.Lines++
-			stat.Commits[commit] = struct{}{}
-			stat.Files[filePath] = struct{}{}
-
-			block = nil
-			continue
+			stats[author] = stat
 		}
-		block = append(block, line)
+		stat.Lines++
+		stat.Commits[bl.Commit] = struct{}{}
+		stat.Files[filePath] = struct{}{}
 	}
 
 	return stats, nil
@@ -195,27 +202,136 @@ func filterByGlob(files []string, patterns []string, include bool) []string {
 	return filtered
 }
 
-// getGitFiles возвращает список файлов в репозитории по заданной ревизии.
-func getGitFiles(repoPath, revision string) ([]string, error) {
-	revCmd := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", revision)
-	if err := revCmd.Run(); err != nil {
-		return nil, fmt.Errorf("неверная ревизия (%s): %v", revision, err)
-	}
-	cmd := exec.Command("git", "-C", repoPath, "ls-tree", "-r", "--name-only", revision)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("git ls-tree failed: %v", err)
-	}
-	rawLines := strings.Split(out.String(), "\n")
-	var lines []string
-	for _, line := range rawLines {
-		if trimmed := strings.TrimSpace(line); len(trimmed) > 0 {
-			lines = append(lines, trimmed)
+// getGitFiles возвращает список файлов в репозитории по заданной ревизии через выбранный Backend.
+func getGitFiles(ctx context.Context, backend Backend, revision string) ([]string, error) {
+	return backend.ListFiles(ctx, revision)
+}
+
+// resolveJobs подбирает число воркеров, когда --jobs не задан явно (requested <= 0).
+// gogitBackend сериализует каждый вызов через мьютекс (см. backend.go), поэтому несколько
+// воркеров на нём просто ждут друг друга в очереди и не дают ускорения — в отличие от
+// execBackend, где каждый воркер порождает независимый процесс git. Явно заданное
+// пользователем значение --jobs всегда используется как есть, даже для go-git.
+func resolveJobs(requested int, backendName string) int {
+	if requested > 0 {
+		return requested
+	}
+	if backendName == "exec" {
+		return runtime.NumCPU()
+	}
+	return 1
+}
+
+// fileResult привязывает результат анализа к исходному файлу, чтобы ошибки
+// можно было сообщать в стабильном порядке независимо от того, какой воркер
+// завершился первым.
+type fileResult struct {
+	file  string
+	stats map[string]*AuthorStats
+	err   error
+}
+
+// collectBlameStats разбирает файлы по пулу воркеров фиксированного размера и
+// сводит их статистику в единую map в отдельной горутине-редьюсере. Ошибки
+// возвращаются в порядке files, поэтому вывод не зависит от того, в каком
+// порядке воркеры закончили работу.
+func collectBlameStats(ctx context.Context, backend Backend, repoPath, revision string, files []string, useCommitter bool, jobs int, ignore IgnoreRevs) (map[string]*AuthorStats, []error) {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	tasks := make(chan int)
+	results := make(chan fileResult)
+
+	var workersDone sync.WaitGroup
+	workersDone.Add(jobs)
+	for w := 0; w < jobs; w++ {
+		go func() {
+			defer workersDone.Done()
+			for idx := range tasks {
+				file := files[idx]
+				var stats map[string]*AuthorStats
+				var err error
+				if isFileEmpty(repoPath, file) {
+					stats, err = blameEmptyFile(ctx, backend, revision, file, useCommitter)
+				} else {
+					stats, err = blameFile(ctx, backend, revision, file, useCommitter, ignore)
+					if err == nil && len(stats) == 0 {
+						stats, err = blameEmptyFile(ctx, backend, revision, file, useCommitter)
+					}
+				}
+				select {
+				case results <- fileResult{file: file, stats: stats, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(tasks)
+		for idx := range files {
+			select {
+			case tasks <- idx:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		workersDone.Wait()
+		close(results)
+	}()
+
+	// Редьюсер складывает результаты по мере поступления, но накапливает их в
+	// срезе по файлам, чтобы итоговая печать ошибок не зависела от порядка
+	// завершения воркеров.
+	perFile := make([]fileResult, len(files))
+	seen := make([]bool, len(files))
+	index := make(map[string]int, len(files))
+	for i, file := range files {
+		index[file] = i
+	}
+
+	for res := range results {
+		i := index[res.file]
+		perFile[i] = res
+		seen[i] = true
+	}
+
+	totalStats := make(map[string]*AuthorStats)
+	var errs []error
+	for i, res := range perFile {
+		if !seen[i] {
+			continue
+		}
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("ошибка анализа файла %s: %v", res.file, res.err))
+			continue
+		}
+		for name, fileStat := range res.stats {
+			stat, ok := totalStats[name]
+			if !ok {
+				stat = &AuthorStats{
+					Name:    name,
+					Commits: make(map[string]struct{}),
+					Files:   make(map[string]struct{}),
+				}
+				totalStats[name] = stat
+			}
+			stat.Lines += fileStat.Lines
+			for commit := range fileStat.Commits {
+				stat.Commits[commit] = struct{}{}
+			}
+			for f := range fileStat.Files {
+				stat.Files[f] = struct{}{}
+			}
 		}
 	}
-	return lines, nil
+
+	return totalStats, errs
 }
 
 func main() {
@@ -234,8 +350,43 @@ func main() {
 	// Путь к конфигурационному файлу с расширениями языков.
 	languageConfigPath := flag.String("languages-config-path", "../../configs/language_extensions.json", "Путь к файлу с расширениями языков (JSON)")
 
+	jobs := flag.Int("jobs", 0, "Количество воркеров для параллельного анализа файлов (0 — выбрать автоматически: "+
+		"NumCPU для --backend=exec, 1 для --backend=go-git, который сериализует доступ к репозиторию через мьютекс "+
+		"и поэтому не ускоряется дополнительными воркерами)")
+	backendName := flag.String("backend", "go-git", "Бэкенд для работы с git: exec или go-git")
+	classifyMode := flag.String("classify", "extension", "Режим классификации языка файлов: off, extension или content")
+
+	// Параметры исключения вендорных/сгенерированных/документационных файлов.
+	includeVendored := flag.Bool("include-vendored", false, "Не исключать вендорные файлы (linguist-vendored)")
+	includeGenerated := flag.Bool("include-generated", false, "Не исключать сгенерированные файлы (linguist-generated)")
+	includeDocumentation := flag.Bool("include-documentation", false, "Не исключать документационные файлы (linguist-documentation)")
+	vendorPatternsPath := flag.String("vendor-patterns-path", "../../configs/vendor_patterns.yml", "Путь к встроенным glob-паттернам вендоринга (YAML)")
+
+	// Параметры игнорирования ревизий при blame (mass-reformat/license-header коммиты).
+	var ignoreRevFlags stringSliceFlag
+	flag.Var(&ignoreRevFlags, "ignore-rev", "SHA коммита, который нужно игнорировать при blame (можно указывать несколько раз)")
+	ignoreRevsFile := flag.String("ignore-revs-file", "", "Файл со списком SHA коммитов для игнорирования при blame")
+	noAutoIgnoreRevs := flag.Bool("no-auto-ignore-revs", false, "Не подхватывать автоматически .git-blame-ignore-revs из корня репозитория")
+
 	flag.Parse()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	backend, err := newBackend(*backendName, *repoPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ошибка инициализации git-бэкенда:", err)
+		os.Exit(1)
+	}
+
+	*jobs = resolveJobs(*jobs, *backendName)
+
+	ignoreRevs, err := resolveIgnoreRevs(*repoPath, ignoreRevFlags, *ignoreRevsFile, !*noAutoIgnoreRevs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ошибка обработки игнорируемых ревизий:", err)
+		os.Exit(1)
+	}
+
 	// Проверяем корректность параметра order-by.
 	validOrderBy := map[string]bool{
 		"lines":   true,
@@ -255,8 +406,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Проверяем корректность режима классификации.
+	switch *classifyMode {
+	case "off", "extension", "content":
+	default:
+		fmt.Fprintf(os.Stderr, "ошибка: --classify может быть только off, extension или content, но получено: %q\n", *classifyMode)
+		os.Exit(1)
+	}
+
 	// Получаем список файлов из git.
-	files, err := getGitFiles(*repoPath, *revision)
+	files, err := getGitFiles(ctx, backend, *revision)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "ошибка получения файлов из git:", err)
 		os.Exit(1)
@@ -270,6 +429,20 @@ func main() {
 	}
 	files = validFiles
 
+	// Исключаем вендорные, сгенерированные и документационные файлы по .gitattributes
+	// (читаем его из рабочего дерева, как и содержимое файлов для классификатора) и
+	// встроенным паттернам из configs/vendor_patterns.yml, если явно не указано обратное.
+	vendorPatterns, err := loadVendorPatterns(*vendorPatternsPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ошибка загрузки паттернов вендоринга:", err)
+		os.Exit(1)
+	}
+	var attrMatcher *gitattr.Matcher
+	if data, attrErr := os.ReadFile(filepath.Join(*repoPath, ".gitattributes")); attrErr == nil {
+		attrMatcher = gitattr.Parse(data)
+	}
+	files = filterVendoredFiles(files, attrMatcher, vendorPatterns, *includeVendored, *includeGenerated, *includeDocumentation)
+
 	// Разбираем параметры languages, exclude, restrict.
 	var langsList, excludeList, restrictList []string
 	if *languages != "" {
@@ -301,6 +474,23 @@ func main() {
 		files = filterExtension(files, extList)
 	}
 
+	// Если задан режим content, дополнительно отбираем файлы по классификации содержимого —
+	// это второй этап фильтрации, следующий за filterExtension, и он нужен, чтобы отсеять
+	// файлы с неоднозначным расширением (.h, .m, .pl), отнесённые к другому языку.
+	if len(langsList) > 0 && *classifyMode == "content" {
+		langs, langErr := loadLanguages(*languageConfigPath)
+		if langErr != nil {
+			fmt.Fprintf(os.Stderr, "ошибка загрузки конфигурации языков для классификации: %v\n", langErr)
+			os.Exit(1)
+		}
+		classifier := NewClassifier(langs)
+		if !classifier.Trained() {
+			fmt.Fprintln(os.Stderr, "предупреждение: конфигурация языков не содержит keywords, классификация по содержимому пропущена")
+		} else {
+			files = filterByClassification(files, *repoPath, classifier, langsList)
+		}
+	}
+
 	// Применяем фильтрацию по исключению (exclude) и ограничению (restrict).
 	if len(excludeList) > 0 {
 		files = filterByGlob(files, excludeList, false)
@@ -309,42 +499,10 @@ func main() {
 		files = filterByGlob(files, restrictList, true)
 	}
 
-	// Сбор общей статистики по всем авторам.
-	totalStats := make(map[string]*AuthorStats)
-	for _, file := range files {
-		var fileStats map[string]*AuthorStats
-
-		if isFileEmpty(*repoPath, file) {
-			fileStats, err = blameEmptyFile(*repoPath, *revision, file, *useCommitter)
-		} else {
-			fileStats, err = blameFile(*repoPath, *revision, file, *useCommitter)
-			if err == nil && len(fileStats) == 0 {
-				fileStats, err = blameEmptyFile(*repoPath, *revision, file, *useCommitter)
-			}
-		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "ошибка анализа файла %s: %v\n", file, err)
-			continue
-		}
-
-		for name, fileStat := range fileStats {
-			stat, ok := totalStats[name]
-			if !ok {
-				stat = &AuthorStats{
-					Name:    name,
-					Commits: make(map[string]struct{}),
-					Files:   make(map[string]struct{}),
-				}
-				totalStats[name] = stat
-			}
-			stat.Lines += fileStat.Lines
-			for commit := range fileStat.Commits {
-				stat.Commits[commit] = struct{}{}
-			}
-			for f := range fileStat.Files {
-				stat.Files[f] = struct{}{}
-			}
-		}
+	// Сбор общей статистики по всем авторам силами пула воркеров.
+	totalStats, blameErrs := collectBlameStats(ctx, backend, *repoPath, *revision, files, *useCommitter, *jobs, ignoreRevs)
+	for _, blameErr := range blameErrs {
+		fmt.Fprintln(os.Stderr, blameErr)
 	}
 
 	var authors []*AuthorStats
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestResolveJobs(t *testing.T) {
+	cases := []struct {
+		name        string
+		requested   int
+		backendName string
+		want        int
+	}{
+		{"explicit value wins on go-git", 4, "go-git", 4},
+		{"explicit value wins on exec", 4, "exec", 4},
+		{"auto on go-git serializes to 1", 0, "go-git", 1},
+		{"negative value treated as auto", -1, "go-git", 1},
+		{"auto on exec uses NumCPU", 0, "exec", runtime.NumCPU()},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := resolveJobs(tc.requested, tc.backendName); got != tc.want {
+				t.Errorf("resolveJobs(%d, %q) = %d, want %d", tc.requested, tc.backendName, got, tc.want)
+			}
+		})
+	}
+}
+
+// fakeBackend — тестовая реализация Backend, которая возвращает предопределённые строки
+// blame или ошибку по имени файла. BlameFile делает короткую паузу перед ответом, чтобы
+// несколько воркеров обрабатывали файлы одновременно и могли завершаться не в порядке
+// files: так проверяется, что collectBlameStats не зависит от порядка завершения воркеров.
+type fakeBackend struct {
+	blameByFile map[string][]BlameLine
+	errByFile   map[string]error
+}
+
+func (b *fakeBackend) ListFiles(ctx context.Context, rev string) ([]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (b *fakeBackend) BlameFile(ctx context.Context, rev, path string, ignore IgnoreRevs) ([]BlameLine, error) {
+	// Пауза в len(path)%3 мс: пока воркер спит, остальные успевают взять следующие файлы,
+	// и порядок завершения не привязан к порядку files.
+	time.Sleep(time.Duration(len(path)%3) * time.Millisecond)
+	if err, ok := b.errByFile[path]; ok {
+		return nil, err
+	}
+	return b.blameByFile[path], nil
+}
+
+func (b *fakeBackend) LastCommit(ctx context.Context, rev, path string) (string, string, string, error) {
+	return "deadbeef", "nobody", "nobody", nil
+}
+
+func TestCollectBlameStatsDeterministicErrorOrder(t *testing.T) {
+	repoPath := t.TempDir()
+	files := []string{"a.go", "b.go", "c.go", "d.go", "e.go"}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(repoPath, f), []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backend := &fakeBackend{
+		blameByFile: map[string][]BlameLine{
+			"a.go": {{Commit: "c1", Author: "alice", Committer: "alice"}},
+		},
+		errByFile: map[string]error{
+			"b.go": fmt.Errorf("boom b"),
+			"d.go": fmt.Errorf("boom d"),
+			"e.go": fmt.Errorf("boom e"),
+		},
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		_, errs := collectBlameStats(context.Background(), backend, repoPath, "HEAD", files, false, 4, IgnoreRevs{})
+		if len(errs) != 3 {
+			t.Fatalf("attempt %d: got %d errors, want 3: %v", attempt, len(errs), errs)
+		}
+		want := []string{
+			"ошибка анализа файла b.go: boom b",
+			"ошибка анализа файла d.go: boom d",
+			"ошибка анализа файла e.go: boom e",
+		}
+		for i, w := range want {
+			if errs[i].Error() != w {
+				t.Errorf("attempt %d: errs[%d] = %q, want %q", attempt, i, errs[i].Error(), w)
+			}
+		}
+	}
+}
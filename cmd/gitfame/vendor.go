@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/makhmudovs1/gitfame/internal/gitattr"
+)
+
+// vendorPatternsConfig отражает структуру configs/vendor_patterns.yml до компиляции
+// паттернов в regexp.
+type vendorPatternsConfig struct {
+	Vendored      []string `yaml:"vendored"`
+	Generated     []string `yaml:"generated"`
+	Documentation []string `yaml:"documentation"`
+}
+
+// VendorPatterns задаёт встроенные наборы glob-паттернов для вендорных, сгенерированных и
+// документационных файлов из configs/vendor_patterns.yml, скомпилированные в regexp один раз
+// при загрузке. Они служат резервным вариантом для путей, по которым .gitattributes не
+// сказал ничего определённого.
+type VendorPatterns struct {
+	Vendored      gitattr.PatternSet
+	Generated     gitattr.PatternSet
+	Documentation gitattr.PatternSet
+}
+
+// loadVendorPatterns читает встроенные паттерны вендоринга из YAML-файла и компилирует их.
+func loadVendorPatterns(path string) (VendorPatterns, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return VendorPatterns{}, fmt.Errorf("не удалось прочитать файл паттернов вендоринга: %v", err)
+	}
+	var config vendorPatternsConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return VendorPatterns{}, fmt.Errorf("ошибка разбора YAML паттернов вендоринга: %v", err)
+	}
+	return VendorPatterns{
+		Vendored:      gitattr.CompilePatterns(config.Vendored),
+		Generated:     gitattr.CompilePatterns(config.Generated),
+		Documentation: gitattr.CompilePatterns(config.Documentation),
+	}, nil
+}
+
+func boolOr(v *bool, fallback bool) bool {
+	if v != nil {
+		return *v
+	}
+	return fallback
+}
+
+// isExcludedFile решает, нужно ли исключить файл из статистики: явный атрибут из
+// .gitattributes всегда побеждает, а встроенные паттерны применяются только тогда, когда
+// .gitattributes не определил соответствующий атрибут для этого пути.
+func isExcludedFile(file string, matcher *gitattr.Matcher, patterns VendorPatterns, includeVendored, includeGenerated, includeDocumentation bool) bool {
+	var attrs gitattr.Attrs
+	if matcher != nil {
+		attrs = matcher.Match(file)
+	}
+
+	if attrs.Detectable != nil && !*attrs.Detectable {
+		return true
+	}
+
+	vendored := boolOr(attrs.Vendored, patterns.Vendored.MatchAny(file))
+	if vendored && !includeVendored {
+		return true
+	}
+
+	generated := boolOr(attrs.Generated, patterns.Generated.MatchAny(file))
+	if generated && !includeGenerated {
+		return true
+	}
+
+	documentation := boolOr(attrs.Documentation, patterns.Documentation.MatchAny(file))
+	if documentation && !includeDocumentation {
+		return true
+	}
+
+	return false
+}
+
+// filterVendoredFiles отбрасывает вендорные, сгенерированные и документационные файлы,
+// опираясь на .gitattributes и встроенные паттерны вендоринга.
+func filterVendoredFiles(files []string, matcher *gitattr.Matcher, patterns VendorPatterns, includeVendored, includeGenerated, includeDocumentation bool) []string {
+	var filtered []string
+	for _, file := range files {
+		if isExcludedFile(file, matcher, patterns, includeVendored, includeGenerated, includeDocumentation) {
+			continue
+		}
+		filtered = append(filtered, file)
+	}
+	return filtered
+}
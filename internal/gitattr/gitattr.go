@@ -0,0 +1,186 @@
+// Package gitattr реализует минимальный парсер .gitattributes, которого gitfame достаточно,
+// чтобы учитывать linguist-атрибуты (linguist-vendored, linguist-generated,
+// linguist-documentation, linguist-detectable) и собственные glob-паттерны вендоринга.
+// Семантика сопоставления паттернов (порядок правил, переопределение более поздними записями,
+// "**" для произвольной глубины каталогов) соответствует тому, что документирует
+// go-git/plumbing/format/gitattributes.
+package gitattr
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Attrs хранит значения linguist-атрибутов для одного файла. Нулевой указатель означает,
+// что ни одно правило .gitattributes не упоминало этот атрибут для данного пути.
+type Attrs struct {
+	Vendored      *bool
+	Generated     *bool
+	Documentation *bool
+	Detectable    *bool
+}
+
+type attrSetting struct {
+	name  string
+	value bool
+}
+
+type rule struct {
+	re    *regexp.Regexp
+	attrs []attrSetting
+}
+
+// Matcher применяет правила .gitattributes к путям файлов в порядке их появления в файле:
+// более поздние совпадающие правила переопределяют значения атрибутов, заданные более
+// ранними — так же, как это делает сам git.
+type Matcher struct {
+	rules []rule
+}
+
+// Parse разбирает содержимое .gitattributes и строит Matcher.
+func Parse(data []byte) *Matcher {
+	m := &Matcher{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		re := compilePattern(fields[0])
+		if re == nil {
+			continue
+		}
+
+		attrs := make([]attrSetting, 0, len(fields)-1)
+		for _, f := range fields[1:] {
+			switch {
+			case strings.HasPrefix(f, "-"):
+				attrs = append(attrs, attrSetting{name: f[1:], value: false})
+			case strings.Contains(f, "="):
+				parts := strings.SplitN(f, "=", 2)
+				attrs = append(attrs, attrSetting{name: parts[0], value: parts[1] != "false"})
+			default:
+				attrs = append(attrs, attrSetting{name: f, value: true})
+			}
+		}
+		m.rules = append(m.rules, rule{re: re, attrs: attrs})
+	}
+	return m
+}
+
+// Match возвращает атрибуты, которые правила .gitattributes присваивают указанному пути.
+func (m *Matcher) Match(filePath string) Attrs {
+	filePath = path.Clean(filePath)
+
+	var a Attrs
+	for _, r := range m.rules {
+		if !r.re.MatchString(filePath) {
+			continue
+		}
+		for _, setting := range r.attrs {
+			value := setting.value
+			switch setting.name {
+			case "linguist-vendored":
+				a.Vendored = &value
+			case "linguist-generated":
+				a.Generated = &value
+			case "linguist-documentation":
+				a.Documentation = &value
+			case "linguist-detectable":
+				a.Detectable = &value
+			}
+		}
+	}
+	return a
+}
+
+// MatchPattern сообщает, соответствует ли filePath одиночному gitignore-подобному
+// glob-паттерну (используется как .gitattributes, так и встроенными паттернами вендоринга
+// из configs/vendor_patterns.yml).
+func MatchPattern(pattern, filePath string) bool {
+	re := compilePattern(pattern)
+	if re == nil {
+		return false
+	}
+	return re.MatchString(path.Clean(filePath))
+}
+
+// PatternSet — набор gitignore-подобных glob-паттернов, скомпилированных в regexp один раз,
+// чтобы проверять принадлежность множества файлов без повторной компиляции на каждую пару
+// (файл, паттерн) — так делает встроенная фильтрация вендоринга, где одни и те же паттерны
+// проверяются для каждого файла репозитория.
+type PatternSet []*regexp.Regexp
+
+// CompilePatterns компилирует список glob-паттернов в PatternSet. Паттерны, которые не
+// удалось скомпилировать, пропускаются молча — так же, как это уже делает MatchPattern.
+func CompilePatterns(patterns []string) PatternSet {
+	set := make(PatternSet, 0, len(patterns))
+	for _, pattern := range patterns {
+		if re := compilePattern(pattern); re != nil {
+			set = append(set, re)
+		}
+	}
+	return set
+}
+
+// MatchAny сообщает, соответствует ли filePath хотя бы одному паттерну набора.
+func (ps PatternSet) MatchAny(filePath string) bool {
+	clean := path.Clean(filePath)
+	for _, re := range ps {
+		if re.MatchString(clean) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePattern переводит gitignore/gitattributes-паттерн в regexp: "*" — любой фрагмент
+// внутри сегмента пути, "**" — произвольная глубина каталогов, паттерн без "/" совпадает с
+// файлом на любом уровне вложенности.
+func compilePattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored && !strings.Contains(pattern, "/") {
+		sb.WriteString("(.*/)?")
+	}
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		rest := string(runes[i:])
+		switch {
+		case strings.HasPrefix(rest, "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(rest, "**"):
+			sb.WriteString(".*")
+			i++
+		case runes[i] == '*':
+			sb.WriteString("[^/]*")
+		case runes[i] == '?':
+			sb.WriteString("[^/]")
+		case strings.ContainsRune(`.+()^$|\`, runes[i]):
+			sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+	sb.WriteString("(/.*)?$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil
+	}
+	return re
+}
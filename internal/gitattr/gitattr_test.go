@@ -0,0 +1,68 @@
+package gitattr
+
+import "testing"
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"doublestar-prefix matches nested dir", "**/vendor/**", "pkg/vendor/lib/foo.go", true},
+		{"doublestar-prefix requires the segment", "**/vendor/**", "pkg/vendored/foo.go", false},
+		{"anchored matches only at root", "/vendor/**", "vendor/lib/foo.go", true},
+		{"anchored rejects nested occurrence", "/vendor/**", "pkg/vendor/lib/foo.go", false},
+		{"no-slash pattern matches at any depth", "LICENSE*", "third_party/foo/LICENSE.txt", true},
+		{"no-slash pattern matches at root", "LICENSE*", "LICENSE", true},
+		{"star matches within a single segment", "*.min.js", "app.min.js", true},
+		{"no-slash star pattern matches nested file", "*.min.js", "dist/app.min.js", true},
+		{"star does not match unrelated suffix", "*.min.js", "app.min.js.map", false},
+		{"trailing doublestar matches directory contents", "vendor/**", "vendor/foo/bar.go", true},
+		{"trailing doublestar requires a path under the dir", "vendor/**", "vendor", false},
+		{"plain segment without wildcards", "docs/**", "docs/readme.md", true},
+		{"plain segment without wildcards (miss)", "docs/**", "src/docs/readme.md", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := MatchPattern(tc.pattern, tc.path); got != tc.want {
+				t.Errorf("MatchPattern(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPatternSetMatchAny(t *testing.T) {
+	set := CompilePatterns([]string{"vendor/**", "**/*.md", "*.min.js"})
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"vendor/lib/foo.go", true},
+		{"README.md", true},
+		{"docs/nested/guide.md", true},
+		{"app.min.js", true},
+		{"main.go", false},
+	}
+	for _, tc := range tests {
+		if got := set.MatchAny(tc.path); got != tc.want {
+			t.Errorf("MatchAny(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestMatcherLaterRuleOverrides(t *testing.T) {
+	m := Parse([]byte(`
+*.h linguist-vendored
+third_party/*.h -linguist-vendored
+`))
+
+	if attrs := m.Match("third_party/foo.h"); attrs.Vendored == nil || *attrs.Vendored {
+		t.Errorf("Match(third_party/foo.h).Vendored = %v, want false (later rule overrides)", attrs.Vendored)
+	}
+	if attrs := m.Match("src/foo.h"); attrs.Vendored == nil || !*attrs.Vendored {
+		t.Errorf("Match(src/foo.h).Vendored = %v, want true", attrs.Vendored)
+	}
+}